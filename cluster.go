@@ -0,0 +1,164 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hossam-houssien/grnode/pkg/dot"
+)
+
+// defaultClusterPalette cycles background colors for path-derived clusters
+// when no per-depth ClusterStyle override is configured.
+var defaultClusterPalette = []string{"#f0f0f8", "#e8f4ea", "#fdf3e3", "#f3e8f4"}
+
+// clusterIDRE strips characters that aren't valid in a bareword DOT ID, so a
+// cluster's Graphviz ID can be derived from an arbitrary path.
+var clusterIDRE = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// ClusterDescription documents one path-derived cluster in the
+// -description-file sidecar, including a collapse/expand hint for viewers
+// that want to let a user fold an entire subtree.
+type ClusterDescription struct {
+	ID          string `json:"id"`
+	Path        string `json:"path"`
+	Label       string `json:"label"`
+	ParentID    string `json:"parent_id,omitempty"`
+	Depth       int    `json:"depth"`
+	Collapsible bool   `json:"collapsible"`
+}
+
+// computeClusters walks the Path tree formed by nodes (splitting on sep) and
+// returns the cluster each node belongs to plus a flat list describing every
+// cluster that needs to exist, in DFS order (parents before children).
+// Nodes with an empty Path are not part of any cluster.
+func computeClusters(nodes []NodeData, sep string, styles map[string]ClusterStyle) (clusterOfNode map[string]string, clusters []ClusterDescription) {
+	clusterOfNode = make(map[string]string, len(nodes))
+
+	prefixSet := map[string]bool{}
+	for _, node := range nodes {
+		if node.Path == "" {
+			continue
+		}
+		segments := strings.Split(node.Path, sep)
+		for i := range segments {
+			prefixSet[strings.Join(segments[:i+1], sep)] = true
+		}
+	}
+
+	prefixes := make([]string, 0, len(prefixSet))
+	for p := range prefixSet {
+		prefixes = append(prefixes, p)
+	}
+	// Compare segment by segment so each prefix is followed by its whole
+	// subtree before its next sibling (a DFS pre-order, parents first).
+	sort.Slice(prefixes, func(i, j int) bool {
+		a, b := strings.Split(prefixes[i], sep), strings.Split(prefixes[j], sep)
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	for _, prefix := range prefixes {
+		depth := strings.Count(prefix, sep)
+		parentID := ""
+		if i := strings.LastIndex(prefix, sep); i >= 0 {
+			parentID = clusterID(prefix[:i])
+		}
+		segments := strings.Split(prefix, sep)
+		clusters = append(clusters, ClusterDescription{
+			ID:          clusterID(prefix),
+			Path:        prefix,
+			Label:       segments[len(segments)-1],
+			ParentID:    parentID,
+			Depth:       depth,
+			Collapsible: true,
+		})
+	}
+
+	for _, node := range nodes {
+		if node.Path != "" {
+			clusterOfNode[node.Name] = clusterID(node.Path)
+		}
+	}
+
+	return clusterOfNode, clusters
+}
+
+// applyClusters groups nodes into nested `subgraph cluster_*` blocks in g,
+// keyed by their Path field (see computeClusters), and adds every node to
+// its cluster (or directly to g, for nodes with no Path). Edges are left
+// for the caller to add at the top level: Graphviz resolves an edge between
+// two node IDs regardless of which subgraph declared them, so clustering
+// never needs to touch edges.
+func applyClusters(g *dot.Graph, nodes []NodeData, ids map[string]string, sep string, styles map[string]ClusterStyle) (clusterOfNode map[string]string, clusters []ClusterDescription) {
+	clusterOfNode, clusters = computeClusters(nodes, sep, styles)
+
+	subgraphs := map[string]*dot.Graph{}
+	for _, c := range clusters {
+		parent := g
+		if c.ParentID != "" {
+			parent = subgraphs[c.ParentID]
+		}
+		sub := parent.AddSubgraph(c.ID)
+		sub.SetAttr("label", c.Label)
+		sub.SetAttr("bgcolor", clusterColor(c.Depth, styles))
+		if style, ok := styles[depthKey(c.Depth)]; ok && style.FontName != "" {
+			sub.SetAttr("fontname", style.FontName)
+		}
+		subgraphs[c.ID] = sub
+	}
+
+	for _, node := range nodes {
+		target := g
+		if id, ok := clusterOfNode[node.Name]; ok {
+			target = subgraphs[id]
+		}
+		n := target.AddNode(ids[node.Name])
+		copyNodeAttrs(n, node)
+	}
+
+	return clusterOfNode, clusters
+}
+
+// copyNodeAttrs applies a NodeData's rendering attributes to n, matching
+// buildDotGraph's defaults for un-clustered nodes. It also sets the DOT
+// "id" attribute explicitly to n.ID: Graphviz's SVG backend only carries a
+// node's DOT id through to the rendered `id=` attribute when it's set this
+// way, otherwise it assigns its own sequential SVG ids that don't match the
+// "n%d" ids written to the -description-file sidecar.
+func copyNodeAttrs(n *dot.Node, node NodeData) {
+	n.SetAttr("id", n.ID)
+	n.SetAttr("label", node.Name)
+	n.SetAttr("URL", node.URL)
+	n.SetAttr("tooltip", node.Synopsis)
+	n.SetAttr("shape", "box")
+	n.SetAttr("style", "filled")
+	n.SetAttr("fillcolor", "#e0e0e0")
+	n.SetAttr("fontname", "Arial")
+	if node.Shape != "" {
+		n.SetAttr("shape", node.Shape)
+	}
+	if node.FillColor != "" {
+		n.SetAttr("fillcolor", node.FillColor)
+	}
+}
+
+func clusterID(path string) string {
+	return "cluster_" + clusterIDRE.ReplaceAllString(path, "_")
+}
+
+func depthKey(depth int) string {
+	return strconv.Itoa(depth)
+}
+
+func clusterColor(depth int, styles map[string]ClusterStyle) string {
+	if style, ok := styles[depthKey(depth)]; ok && style.BackgroundColor != "" {
+		return style.BackgroundColor
+	}
+	return defaultClusterPalette[depth%len(defaultClusterPalette)]
+}
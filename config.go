@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// knownGraphAttributes, knownNodeAttributes and knownEdgeAttributes form the
+// schema against which a config file's available_*_attributes allow-lists
+// are validated: any entry not in the corresponding set is rejected.
+var (
+	knownGraphAttributes = map[string]bool{
+		"bgcolor": true, "fontname": true,
+	}
+	knownNodeAttributes = map[string]bool{
+		"name": true, "path": true, "synopsis": true, "url": true,
+		"shape": true, "fillcolor": true,
+	}
+	knownEdgeAttributes = map[string]bool{
+		"from_name": true, "to_name": true,
+		"relation": true, "color": true, "style": true,
+	}
+)
+
+// LoadConfig reads and validates a TOML config file at path, such as the one
+// produced by -genconfig. It does not apply command-line overrides; call
+// mergeConfig for that.
+func LoadConfig(path string) (*Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file %s: %v", path, err)
+	}
+
+	var cfg Config
+	meta, err := toml.Decode(string(raw), &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		key := undecoded[0]
+		line, col := locate(raw, key[len(key)-1])
+		return nil, fmt.Errorf("%s:%d:%d: unknown key %q", path, line, col, key.String())
+	}
+
+	if err := validateAttributes(path, raw, "available_graph_attributes", cfg.AvailableGraphAttributes, knownGraphAttributes); err != nil {
+		return nil, err
+	}
+	if err := validateAttributes(path, raw, "available_node_attributes", cfg.AvailableNodeAttributes, knownNodeAttributes); err != nil {
+		return nil, err
+	}
+	if err := validateAttributes(path, raw, "available_edge_attributes", cfg.AvailableEdgeAttributes, knownEdgeAttributes); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// validateAttributes reports an error naming the offending key and value,
+// and the line/column in raw where that value appears, when attrs contains
+// an entry not present in known.
+func validateAttributes(path string, raw []byte, key string, attrs []string, known map[string]bool) error {
+	for _, a := range attrs {
+		if !known[a] {
+			line, col := locate(raw, `"`+a+`"`)
+			return fmt.Errorf("%s:%d:%d: %s contains unknown attribute %q", path, line, col, key, a)
+		}
+	}
+	return nil
+}
+
+// locate returns the 1-indexed line and column of the first occurrence of
+// needle in raw, or (0, 0) if it isn't found. It's a plain text search, not
+// a TOML-aware one, so it can be fooled by a needle that also appears in a
+// comment or a string earlier in the file; that's an acceptable tradeoff for
+// pointing a user at approximately the right spot without a full TOML AST.
+func locate(raw []byte, needle string) (line, col int) {
+	for i, l := range strings.Split(string(raw), "\n") {
+		if idx := strings.Index(l, needle); idx >= 0 {
+			return i + 1, idx + 1
+		}
+	}
+	return 0, 0
+}
+
+// mergeConfig layers cfg (from a config file, may be nil) over built-in
+// defaults, then layers command-line flag values on top wherever the flag
+// was explicitly set. Precedence: flags > file > defaults.
+func mergeConfig(cfg *Config, flagSet map[string]string) Config {
+	merged := Config{
+		Graph: GraphMetadata{
+			Name: "MyGraph",
+		},
+		NodeFormat:       "name|path|synopsis|url",
+		EdgeFormat:       "from_name,to_name[,relation,color,style]",
+		ClusterSeparator: "/",
+	}
+
+	if cfg != nil {
+		if cfg.Graph.Name != "" {
+			merged.Graph.Name = cfg.Graph.Name
+		}
+		if cfg.Graph.BackgroundColor != "" {
+			merged.Graph.BackgroundColor = cfg.Graph.BackgroundColor
+		}
+		if cfg.Graph.FontName != "" {
+			merged.Graph.FontName = cfg.Graph.FontName
+		}
+		if cfg.NodeFormat != "" {
+			merged.NodeFormat = cfg.NodeFormat
+		}
+		if cfg.EdgeFormat != "" {
+			merged.EdgeFormat = cfg.EdgeFormat
+		}
+		if cfg.ClusterSeparator != "" {
+			merged.ClusterSeparator = cfg.ClusterSeparator
+		}
+		merged.ClusterByPath = cfg.ClusterByPath
+		merged.ClusterStyle = cfg.ClusterStyle
+		merged.AvailableGraphAttributes = cfg.AvailableGraphAttributes
+		merged.AvailableNodeAttributes = cfg.AvailableNodeAttributes
+		merged.AvailableEdgeAttributes = cfg.AvailableEdgeAttributes
+	}
+
+	if name, ok := flagSet["name"]; ok {
+		merged.Graph.Name = name
+	}
+	if bg, ok := flagSet["bgcolor"]; ok {
+		merged.Graph.BackgroundColor = bg
+	}
+	if fn, ok := flagSet["fontname"]; ok {
+		merged.Graph.FontName = fn
+	}
+
+	return merged
+}
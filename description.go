@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// NodeDescription is the fully-resolved view of a single node written to the
+// -description-file sidecar. ID matches the node's "n%d" identifier in the
+// rendered DOT/SVG, so downstream tools can cross-reference the two without
+// re-parsing either.
+type NodeDescription struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Path      string   `json:"path,omitempty"`
+	Synopsis  string   `json:"synopsis,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	ClusterID string   `json:"cluster_id,omitempty"`
+	Incoming  []string `json:"incoming"`
+	Outgoing  []string `json:"outgoing"`
+	Degree    int      `json:"degree"`
+}
+
+// EdgeDescription is the fully-resolved view of a single edge written to the
+// -description-file sidecar.
+type EdgeDescription struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	FromName string `json:"from_name"`
+	ToName   string `json:"to_name"`
+	Relation string `json:"relation,omitempty"`
+	Color    string `json:"color,omitempty"`
+	Style    string `json:"style,omitempty"`
+}
+
+// GraphDescription is the top-level shape of the -description-file sidecar:
+// a structured, machine-readable view of everything that was rendered.
+type GraphDescription struct {
+	Graph    GraphMetadata        `json:"graph"`
+	Nodes    []NodeDescription    `json:"nodes"`
+	Edges    []EdgeDescription    `json:"edges"`
+	Clusters []ClusterDescription `json:"clusters,omitempty"`
+}
+
+// buildDescription resolves nodes/edges into a GraphDescription, computing
+// each node's incoming/outgoing neighbor node IDs and degree. When cfg.ClusterByPath
+// is set, it also resolves each node's cluster and lists every cluster
+// created from the Path tree (see applyClusters).
+func buildDescription(cfg Config, nodes []NodeData, edges []EdgeData) (*GraphDescription, error) {
+	ids, err := buildNodeIDs(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusterOfNode map[string]string
+	var clusters []ClusterDescription
+	if cfg.ClusterByPath {
+		clusterOfNode, clusters = computeClusters(nodes, cfg.ClusterSeparator, cfg.ClusterStyle)
+	}
+
+	descNodes := make([]NodeDescription, len(nodes))
+	byID := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		id := ids[node.Name]
+		byID[id] = i
+		descNodes[i] = NodeDescription{
+			ID:        id,
+			Name:      node.Name,
+			Path:      node.Path,
+			Synopsis:  node.Synopsis,
+			URL:       node.URL,
+			ClusterID: clusterOfNode[node.Name],
+			Incoming:  []string{},
+			Outgoing:  []string{},
+		}
+	}
+
+	descEdges := make([]EdgeDescription, 0, len(edges))
+	for _, edge := range edges {
+		fromID, fromFound := ids[edge.From]
+		toID, toFound := ids[edge.To]
+		if !fromFound || !toFound {
+			return nil, fmt.Errorf("error: edge refers to unknown node(s) from: %s, to: %s", edge.From, edge.To)
+		}
+		descEdges = append(descEdges, EdgeDescription{
+			From:     fromID,
+			To:       toID,
+			FromName: edge.From,
+			ToName:   edge.To,
+			Relation: edge.Relation,
+			Color:    edge.Color,
+			Style:    edge.Style,
+		})
+
+		fromIdx, toIdx := byID[fromID], byID[toID]
+		descNodes[fromIdx].Outgoing = append(descNodes[fromIdx].Outgoing, toID)
+		descNodes[toIdx].Incoming = append(descNodes[toIdx].Incoming, fromID)
+	}
+
+	for i := range descNodes {
+		descNodes[i].Degree = len(descNodes[i].Incoming) + len(descNodes[i].Outgoing)
+	}
+
+	return &GraphDescription{
+		Graph:    cfg.Graph,
+		Nodes:    descNodes,
+		Edges:    descEdges,
+		Clusters: clusters,
+	}, nil
+}
+
+// writeDescriptionFile marshals desc as indented JSON and writes it to path.
+func writeDescriptionFile(path string, desc *GraphDescription) error {
+	data, err := json.MarshalIndent(desc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling description: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing description file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// formatColumns parses a NodeFormat/EdgeFormat spec such as
+// "name|path|synopsis|url" or "from_name,to_name[,relation,color,style]"
+// into an ordered list of column names and the delimiter used to separate
+// them. Square brackets marking optional trailing columns are stripped;
+// whether a column is actually present on a given line is determined by how
+// many fields that line has, not by the brackets.
+func formatColumns(format string) (columns []string, delim string) {
+	format = strings.NewReplacer("[", "", "]", "").Replace(format)
+	delim = "|"
+	if strings.Contains(format, ",") {
+		delim = ","
+	}
+	for _, col := range strings.Split(format, delim) {
+		columns = append(columns, strings.TrimSpace(col))
+	}
+	return columns, delim
+}
+
+// parseNodeLine parses a single node-data line according to the given
+// column order (see formatColumns), so callers can reorder or add columns
+// (e.g. "name|url|synopsis|shape|fillcolor") without recompiling.
+func parseNodeLine(line string, columns []string, delim string) (NodeData, error) {
+	fields := strings.Split(line, delim)
+	if len(fields) > len(columns) {
+		return NodeData{}, fmt.Errorf("too many fields (expected at most %d matching %q): %s", len(columns), strings.Join(columns, delim), line)
+	}
+
+	var node NodeData
+	for i, field := range fields {
+		value := strings.TrimSpace(field)
+		switch columns[i] {
+		case "name":
+			node.Name = value
+		case "path":
+			node.Path = value
+		case "synopsis":
+			node.Synopsis = value
+		case "url":
+			node.URL = value
+		case "shape":
+			node.Shape = value
+		case "fillcolor":
+			node.FillColor = value
+		default:
+			return NodeData{}, fmt.Errorf("unknown node column %q", columns[i])
+		}
+	}
+	if node.Name == "" {
+		return NodeData{}, fmt.Errorf("node line is missing required \"name\" field: %s", line)
+	}
+	return node, nil
+}
+
+// parseEdgeLine parses a single edge-data line according to the given
+// column order (see formatColumns). Only from_name and to_name are
+// required; all other recognized columns are optional.
+func parseEdgeLine(line string, columns []string, delim string) (EdgeData, error) {
+	fields := strings.Split(line, delim)
+	if len(fields) > len(columns) {
+		return EdgeData{}, fmt.Errorf("too many fields (expected at most %d matching %q): %s", len(columns), strings.Join(columns, delim), line)
+	}
+
+	var edge EdgeData
+	for i, field := range fields {
+		value := strings.TrimSpace(field)
+		switch columns[i] {
+		case "from_name":
+			edge.From = value
+		case "to_name":
+			edge.To = value
+		case "relation":
+			edge.Relation = value
+		case "color":
+			edge.Color = value
+		case "style":
+			edge.Style = value
+		default:
+			return EdgeData{}, fmt.Errorf("unknown edge column %q", columns[i])
+		}
+	}
+	if edge.From == "" || edge.To == "" {
+		return EdgeData{}, fmt.Errorf("edge line is missing required from_name/to_name fields: %s", line)
+	}
+	return edge, nil
+}
+
+// readNodesFile reads and parses path as a node-data text file: one node per
+// line, in the column order given by columns/delim, with blank lines and
+// "#"-prefixed comments ignored.
+func readNodesFile(path string, columns []string, delim string) ([]NodeData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading nodes file: %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	nodes := make([]NodeData, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		node, err := parseNodeLine(line, columns, delim)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node in %s: %v", path, err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// readEdgesFile reads and parses path as an edge-data text file, analogous
+// to readNodesFile.
+func readEdgesFile(path string, columns []string, delim string) ([]EdgeData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading edges file: %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	edges := make([]EdgeData, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		edge, err := parseEdgeLine(line, columns, delim)
+		if err != nil {
+			return nil, fmt.Errorf("invalid edge in %s: %v", path, err)
+		}
+		edges = append(edges, edge)
+	}
+	return edges, nil
+}
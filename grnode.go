@@ -1,29 +1,30 @@
 package main
 
 import (
-	"bytes"
-	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"text/template"
+
+	"github.com/hossam-houssien/grnode/pkg/dot"
 )
 
 // 1.  Simplified struct to hold node data.
 type NodeData struct {
-	Name     string
-	Path     string
-	Synopsis string
-	URL      string
+	Name      string
+	Path      string
+	Synopsis  string
+	URL       string
+	Shape     string // Optional; overrides the default node shape
+	FillColor string // Optional; overrides the default node fillcolor
 }
 
 // 2. Struct for graph metadata.
 type GraphMetadata struct {
-	Name        string
-	BackgroundColor string
-	FontName    string
+	Name            string `toml:"name" json:"name"`
+	BackgroundColor string `toml:"background_color" json:"background_color"`
+	FontName        string `toml:"font_name" json:"font_name"`
 }
 
 // 3.  Struct to hold edge data.
@@ -37,12 +38,22 @@ type EdgeData struct {
 
 // 4. Config struct to hold all configuration.
 type Config struct {
-	Graph       GraphMetadata
-	NodeFormat  string
-	EdgeFormat  string
-	AvailableGraphAttributes    []string
-	AvailableNodeAttributes     []string
-	AvailableEdgeAttributes     []string
+	Graph                    GraphMetadata           `toml:"graph"`
+	NodeFormat               string                  `toml:"node_format"`
+	EdgeFormat               string                  `toml:"edge_format"`
+	AvailableGraphAttributes []string                `toml:"available_graph_attributes"`
+	AvailableNodeAttributes  []string                `toml:"available_node_attributes"`
+	AvailableEdgeAttributes  []string                `toml:"available_edge_attributes"`
+	ClusterByPath            bool                    `toml:"cluster_by_path"`
+	ClusterSeparator         string                  `toml:"cluster_separator"`
+	ClusterStyle             map[string]ClusterStyle `toml:"cluster_style"`
+}
+
+// ClusterStyle overrides the appearance of path-derived clusters at a given
+// depth. Depth 0 is the outermost cluster. See Config.ClusterStyle.
+type ClusterStyle struct {
+	BackgroundColor string `toml:"background_color"`
+	FontName        string `toml:"font_name"`
 }
 
 // 5. Constants
@@ -51,12 +62,6 @@ const (
 	defaultConfigTemplate = `
 # Default Configuration for Graphviz Generator
 
-# Graph Metadata
-[graph]
-name = "MyGraph"
-# background_color = "lightgray" # Optional
-# font_name = "Arial" # Optional
-
 # Node Data File Format
 # Example: name|path|synopsis|url
 node_format = "name|path|synopsis|url"
@@ -75,110 +80,135 @@ available_node_attributes = ["name", "path", "synopsis", "url"]
 # Available Edge Attributes in the edge data file
 # These can be used in the edge_format
 available_edge_attributes = ["relation", "color", "style"]
+
+# Group nodes into nested Graphviz clusters by their Path field.
+# cluster_by_path = true
+# cluster_separator = "/"
+
+# Graph Metadata
+[graph]
+name = "MyGraph"
+# background_color = "lightgray" # Optional
+# font_name = "Arial" # Optional
+
+# Optional per-depth style overrides for path-derived clusters.
+# Depth 0 is the outermost cluster.
+# [cluster_style.0]
+# background_color = "#f0f0f8"
 `
 )
 
-func renderGraph(graphMeta GraphMetadata, nodes []NodeData, edges []EdgeData, dotOutput string) ([]byte, error) {
-	var in, out bytes.Buffer
+// buildNodeIDs assigns each node a stable ID ("n%d", positional) and returns
+// a lookup from node name to that ID. The same IDs are used in the rendered
+// DOT/SVG (as node names and `id=` attributes) and in the description
+// sidecar (-description-file), so the two can be cross-referenced. It
+// returns an error if two nodes share a Name, since edges and the
+// description sidecar both resolve nodes by Name and silently collapsing
+// the duplicates would drop one node's attributes with no indication why.
+func buildNodeIDs(nodes []NodeData) (map[string]string, error) {
+	ids := make(map[string]string, len(nodes))
+	for i, node := range nodes {
+		if _, exists := ids[node.Name]; exists {
+			return nil, fmt.Errorf("error: duplicate node name %q", node.Name)
+		}
+		ids[node.Name] = fmt.Sprintf("n%d", i)
+	}
+	return ids, nil
+}
 
-	// Graph styling
-	fmt.Fprintf(&in, "digraph %s { \n", graphMeta.Name)
+// buildDotGraph translates the parsed nodes/edges into an in-memory
+// *dot.Graph, ready to be marshaled or rendered. When cfg.ClusterByPath is
+// set, nodes are grouped into nested clusters by their Path field (see
+// applyClusters); the second return value then maps node name to cluster ID.
+func buildDotGraph(cfg Config, nodes []NodeData, edges []EdgeData) (*dot.Graph, map[string]string, error) {
+	graphMeta := cfg.Graph
+	g := dot.New(graphMeta.Name)
 	if graphMeta.BackgroundColor != "" {
-		fmt.Fprintf(&in, "  bgcolor=\"%s\";\n", graphMeta.BackgroundColor)
+		g.SetAttr("bgcolor", graphMeta.BackgroundColor)
 	}
 	if graphMeta.FontName != "" {
-		fmt.Fprintf(&in, "  fontname=\"%s\";\n", graphMeta.FontName)
+		g.SetAttr("fontname", graphMeta.FontName)
 	}
-	// Default node styling
-	fmt.Fprintf(&in, "  node [shape=box, style=filled, fillcolor=\"#e0e0e0\", fontname=\"Arial\"];\n")
-	// Default edge styling
-	fmt.Fprintf(&in, "  edge [color=\"#555555\", fontname=\"Arial\"];\n")
 
-	for i, node := range nodes {
-		fmt.Fprintf(&in, "  n%d [label=\"%s\", URL=\"%s\", tooltip=\"%s\"];\n",
-			i, node.Name, node.URL,
-			strings.Replace(node.Synopsis, `"`, `\"`, -1))
+	nodeIDs, err := buildNodeIDs(nodes)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	// Create a map to look up node indices by name.
-	nodeIndexMap := make(map[string]int)
-	for i, node := range nodes {
-		nodeIndexMap[node.Name] = i
+	var clusterOfNode map[string]string
+	if cfg.ClusterByPath {
+		clusterOfNode, _ = applyClusters(g, nodes, nodeIDs, cfg.ClusterSeparator, cfg.ClusterStyle)
+	} else {
+		for _, node := range nodes {
+			id := nodeIDs[node.Name]
+			n := g.AddNode(id)
+			copyNodeAttrs(n, node)
+		}
 	}
 
 	for _, edge := range edges {
-		fromIndex, fromFound := nodeIndexMap[edge.From]
-		toIndex, toFound := nodeIndexMap[edge.To]
+		fromID, fromFound := nodeIDs[edge.From]
+		toID, toFound := nodeIDs[edge.To]
 		if !fromFound || !toFound {
-			return nil, fmt.Errorf("error: edge refers to unknown node(s) from: %s, to: %s", edge.From, edge.To)
+			return nil, nil, fmt.Errorf("error: edge refers to unknown node(s) from: %s, to: %s", edge.From, edge.To)
 		}
-		fmt.Fprintf(&in, "  n%d -> n%d", fromIndex, toIndex)
-		hasAttributes := false
-		if edge.Relation != "" {
-			fmt.Fprintf(&in, " [label=\"%s\"", edge.Relation)
-			hasAttributes = true
+		e := g.AddEdge(fromID, toID)
+		e.SetAttr("label", edge.Relation)
+		e.SetAttr("color", edge.Color)
+		e.SetAttr("style", edge.Style)
+		if edge.Color == "" {
+			e.SetAttr("color", "#555555")
 		}
-		if edge.Color != "" {
-			if !hasAttributes {
-				fmt.Fprintf(&in, " [color=\"%s\"", edge.Color)
-				hasAttributes = true
-			} else {
-				fmt.Fprintf(&in, ", color=\"%s\"", edge.Color)
-			}
+		e.SetAttr("fontname", "Arial")
+	}
 
-		}
-		if edge.Style != "" {
-			if !hasAttributes {
-				fmt.Fprintf(&in, " [style=\"%s\"", edge.Style)
-			} else {
-				fmt.Fprintf(&in, ", style=\"%s\"", edge.Style)
-			}
-		}
-		if hasAttributes {
-			fmt.Fprintf(&in, "]")
-		}
-		fmt.Fprintf(&in, ";\n")
+	return g, clusterOfNode, nil
+}
+
+func renderGraph(cfg Config, nodes []NodeData, edges []EdgeData, dotOutput string, renderer Renderer, format string) ([]byte, error) {
+	g, _, err := buildDotGraph(cfg, nodes, edges)
+	if err != nil {
+		return nil, err
 	}
-	in.WriteString("}")
+	dotSource := g.Marshal()
 
 	if dotOutput != "" {
-		err := os.WriteFile(dotOutput, in.Bytes(), 0644)
-		if err != nil {
+		if err := os.WriteFile(dotOutput, dotSource, 0644); err != nil {
 			return nil, fmt.Errorf("error writing DOT file: %v", err)
 		}
 		fmt.Printf("DOT output written to %s\n", dotOutput)
 	}
 
-	cmd := exec.Command("dot", "-Tsvg")
-	cmd.Stdin = &in
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
-		return nil, err
-	}
-
-	p := out.Bytes()
-	i := bytes.Index(p, []byte("<svg"))
-	if i < 0 {
-		return nil, errors.New("<svg not found")
-	}
-	p = p[i:]
-	return p, nil
+	return renderer.Render(dotSource, format)
 }
 
 func main() {
 	// 6. Define command-line flags
 	nodesFile := flag.String("nodes", "nodes.txt", "File containing node information")
 	edgesFile := flag.String("edges", "edges.txt", "File containing edge information")
+	inputFormat := flag.String("input-format", "text", "Input format: text, dot, json, pprof")
+	inputFile := flag.String("input", "", "File to import a graph from when -input-format is not \"text\"")
 	outputFile := flag.String("output", "graph.svg", "File to write the SVG output")
-	graphName := flag.String("name", "MyGraph", "Name of the graph")
-	graphBgColor := flag.String("bgcolor", "", "Background color of the graph")
-	graphFontName := flag.String("fontname", "", "Font name for the graph")
+	formatFlag := flag.String("format", "", "Output format: svg, png, pdf, dot, json, plain, xdot, ps (default: auto-detect from -output extension)")
+	noGraphviz := flag.Bool("no-graphviz", false, "Skip shelling out to Graphviz; only the \"dot\" format is available")
+	flag.String("name", "MyGraph", "Name of the graph")
+	flag.String("bgcolor", "", "Background color of the graph")
+	flag.String("fontname", "", "Font name for the graph")
 	dotOutputFile := flag.String("dot", "", "File to write the DOT output for debugging")
+	descriptionFile := flag.String("description-file", "", "File to write a JSON description of every rendered node and edge")
+	configFile := flag.String("config", "", "Path to a TOML config file (see -genconfig)")
+	serveAddr := flag.String("serve", "", "Address (e.g. \":8080\") to serve the graph over HTTP, re-rendering on changes to -nodes/-edges, instead of writing -output once")
 	generateConfig := flag.Bool("genconfig", false, "Generate a default configuration file")
 	versionFlag := flag.Bool("version", false, "Show version and exit") // New version flag
 
 	flag.Parse()
 
+	// Track which flags the user actually set, so mergeConfig can tell an
+	// explicit override apart from a flag's zero-value default.
+	setFlags := map[string]string{}
+	flag.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = f.Value.String()
+	})
+
 	if *versionFlag {
 		fmt.Printf("Graphviz Graph Generator version %s\n", version)
 		os.Exit(0)
@@ -195,89 +225,116 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Error executing config template: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Println("\nDefault configuration written to standard output.  Save to config.toml and edit.")
+		fmt.Fprintln(os.Stderr, "\nDefault configuration written to standard output.  Save to config.toml and edit.")
 		os.Exit(0)
 	}
 
-	// 7. Read node data from file
-	nodeLines, err := os.ReadFile(*nodesFile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading nodes file: %v\n", err)
-		os.Exit(1)
-	}
-	nodeStrings := strings.Split(string(nodeLines), "\n")
-	nodes := make([]NodeData, 0, len(nodeStrings))
-	for _, line := range nodeStrings {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		parts := strings.Split(line, "|")
-		if len(parts) != 4 {
-			fmt.Fprintf(os.Stderr, "Error: Invalid node format in %s: %s (expected 'name|path|synopsis|url')\n", *nodesFile, line)
+	// 7. Load the config file, if any, and merge it with the flags above.
+	var fileCfg *Config
+	if *configFile != "" {
+		var err error
+		fileCfg, err = LoadConfig(*configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 			os.Exit(1)
 		}
-		nodes = append(nodes, NodeData{
-			Name:     strings.TrimSpace(parts[0]),
-			Path:     strings.TrimSpace(parts[1]),
-			Synopsis: strings.TrimSpace(parts[2]),
-			URL:      strings.TrimSpace(parts[3]),
-		})
 	}
+	cfg := mergeConfig(fileCfg, setFlags)
 
-	// 8. Read edge data from file
-	edgeLines, err := os.ReadFile(*edgesFile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading edges file: %v\n", err)
+	if !isSupportedInputFormat(*inputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unsupported -input-format %q (supported: %s)\n", *inputFormat, strings.Join(supportedInputFormats, ", "))
 		os.Exit(1)
 	}
-	edgeStrings := strings.Split(string(edgeLines), "\n")
-	edges := make([]EdgeData, 0, len(edgeStrings))
-	for _, line := range edgeStrings {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+
+	var nodes []NodeData
+	var edges []EdgeData
+
+	if *inputFormat == "text" {
+		nodeColumns, nodeDelim := formatColumns(cfg.NodeFormat)
+		edgeColumns, edgeDelim := formatColumns(cfg.EdgeFormat)
+
+		// 8. Read node and edge data from file
+		var err error
+		nodes, err = readNodesFile(*nodesFile, nodeColumns, nodeDelim)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
-		parts := strings.Split(line, ",")
-		if len(parts) < 2 { // Changed to allow edges with only from,to
-			fmt.Fprintf(os.Stderr, "Error: Invalid edge format in %s: %s (expected 'from_name,to_name[,relation,color,style]')\n", *edgesFile, line)
+		edges, err = readEdgesFile(*edgesFile, edgeColumns, edgeDelim)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		if *inputFile == "" {
+			fmt.Fprintf(os.Stderr, "Error: -input is required when -input-format is %q\n", *inputFormat)
 			os.Exit(1)
 		}
-		edgeData := EdgeData{
-			From:  strings.TrimSpace(parts[0]),
-			To:    strings.TrimSpace(parts[1]),
+		data, err := os.ReadFile(*inputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading input file: %v\n", err)
+			os.Exit(1)
 		}
-		if len(parts) > 2 && strings.TrimSpace(parts[2]) != "" {
-			edgeData.Relation = strings.TrimSpace(parts[2])
+		nodes, edges, err = importGraph(*inputFormat, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error importing %s: %v\n", *inputFile, err)
+			os.Exit(1)
 		}
-		if len(parts) > 3 && strings.TrimSpace(parts[3]) != "" {
-			edgeData.Color = strings.TrimSpace(parts[3])
+	}
+
+	if *serveAddr != "" {
+		if *inputFormat != "text" {
+			fmt.Fprintf(os.Stderr, "Error: -serve requires -input-format=text, since it watches -nodes/-edges for changes\n")
+			os.Exit(1)
 		}
-		if len(parts) > 4 && strings.TrimSpace(parts[4]) != "" {
-			edgeData.Style = strings.TrimSpace(parts[4])
+		if err := serve(*serveAddr, cfg, nodes, edges, *nodesFile, *edgesFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error serving graph: %v\n", err)
+			os.Exit(1)
 		}
-		edges = append(edges, edgeData)
+		return
+	}
+
+	// 10. Determine the output format and renderer to use.
+	format := *formatFlag
+	if format == "" {
+		format = detectFormat(*outputFile)
+	} else if !isSupportedFormat(format) {
+		fmt.Fprintf(os.Stderr, "Error: unsupported -format %q (supported: %s)\n", format, strings.Join(supportedFormats, ", "))
+		os.Exit(1)
 	}
 
-	// 9. Call the renderGraph function
-	graphMeta := GraphMetadata{
-		Name:            *graphName,
-		BackgroundColor: *graphBgColor,
-		FontName:        *graphFontName,
+	var renderer Renderer = GraphvizRenderer{}
+	if *noGraphviz {
+		renderer = DotRenderer{}
 	}
-	svgData, err := renderGraph(graphMeta, nodes, edges, *dotOutputFile)
+
+	// 11. Call the renderGraph function
+	graphData, err := renderGraph(cfg, nodes, edges, *dotOutputFile, renderer, format)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating graph: %v\n", err)
 		os.Exit(1)
 	}
 
-	// 10. Write the SVG data to the output file
-	err = os.WriteFile(*outputFile, svgData, 0644)
+	if *descriptionFile != "" {
+		desc, err := buildDescription(cfg, nodes, edges)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building description: %v\n", err)
+			os.Exit(1)
+		}
+		if err := writeDescriptionFile(*descriptionFile, desc); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing description: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Description written to %s\n", *descriptionFile)
+	}
+
+	// 12. Write the rendered output to the output file
+	err = os.WriteFile(*outputFile, graphData, 0644)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error writing to %s: %v\n", *outputFile, err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("Successfully wrote graph SVG to %s\n", *outputFile)
+	fmt.Printf("Successfully wrote %s graph to %s\n", format, *outputFile)
 }
 
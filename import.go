@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// supportedInputFormats lists the values accepted by -input-format.
+var supportedInputFormats = []string{"text", "dot", "json", "pprof"}
+
+func isSupportedInputFormat(format string) bool {
+	for _, f := range supportedInputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// importGraph parses data according to format ("dot", "json" or "pprof")
+// into the same []NodeData/[]EdgeData shape produced by the text importer,
+// so graphs from other tools can be re-rendered with this module's styling
+// and clustering. "text" is not handled here: it reads from the separate
+// -nodes/-edges files rather than a single -input file.
+func importGraph(format string, data []byte) ([]NodeData, []EdgeData, error) {
+	switch format {
+	case "dot":
+		return importDOT(data)
+	case "json":
+		return importGonumJSON(data)
+	case "pprof":
+		return importPprofJSON(data)
+	default:
+		return nil, nil, fmt.Errorf("importGraph: unsupported input format %q", format)
+	}
+}
+
+// dotNodeStmtRE matches a DOT node statement with a bracketed (possibly
+// empty) attribute list, e.g. `n0 [label="foo", URL="bar"];` or `n0 [];`.
+var dotNodeStmtRE = regexp.MustCompile(`^\s*"?([A-Za-z0-9_]+)"?\s*\[([^]]*)\]\s*;?\s*$`)
+
+// dotEdgeStmtRE matches a DOT edge statement, e.g. `n0 -> n1 [label="x"];`.
+var dotEdgeStmtRE = regexp.MustCompile(`^\s*"?([A-Za-z0-9_]+)"?\s*->\s*"?([A-Za-z0-9_]+)"?\s*(?:\[([^]]*)\])?\s*;?\s*$`)
+
+var dotAttrRE = regexp.MustCompile(`([A-Za-z0-9_]+)\s*=\s*"((?:[^"\\]|\\.)*)"`)
+
+// importDOT parses a (single, flat) DOT graph back into nodes and edges.
+// It is a minimal line-oriented lexer, not a full DOT grammar: it
+// recognizes one node or edge statement per line, with attributes as
+// `key="value"` pairs, which is the shape this tool itself emits (see
+// pkg/dot). Subgraphs/clusters and multi-line statements are not supported.
+func importDOT(data []byte) ([]NodeData, []EdgeData, error) {
+	var nodes []NodeData
+	var rawEdges []EdgeData
+	seen := map[string]bool{}
+	idToName := map[string]string{}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if m := dotEdgeStmtRE.FindStringSubmatch(line); m != nil {
+			attrs := parseDotAttrs(m[3])
+			rawEdges = append(rawEdges, EdgeData{
+				From:     m[1],
+				To:       m[2],
+				Relation: attrs["label"],
+				Color:    attrs["color"],
+				Style:    attrs["style"],
+			})
+			continue
+		}
+
+		if m := dotNodeStmtRE.FindStringSubmatch(line); m != nil {
+			id := m[1]
+			if id == "digraph" || id == "graph" || id == "node" || id == "edge" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			attrs := parseDotAttrs(m[2])
+			name := attrs["label"]
+			if name == "" {
+				name = id
+			}
+			idToName[id] = name
+			nodes = append(nodes, NodeData{
+				Name:     name,
+				URL:      attrs["URL"],
+				Synopsis: attrs["tooltip"],
+			})
+		}
+	}
+
+	edges := make([]EdgeData, len(rawEdges))
+	for i, e := range rawEdges {
+		e.From = resolveImportID(idToName, e.From)
+		e.To = resolveImportID(idToName, e.To)
+		edges[i] = e
+	}
+
+	return nodes, edges, nil
+}
+
+// resolveImportID translates a raw node ID (from a DOT node statement or a
+// gonum JSON node object) to the Name it was imported under, so edges end
+// up keyed the same way as nodes. An ID with no corresponding node entry
+// (malformed input) passes through unchanged; buildDotGraph will reject it
+// as referring to an unknown node.
+func resolveImportID(idToName map[string]string, id string) string {
+	if name, ok := idToName[id]; ok {
+		return name
+	}
+	return id
+}
+
+func parseDotAttrs(s string) map[string]string {
+	attrs := map[string]string{}
+	for _, m := range dotAttrRE.FindAllStringSubmatch(s, -1) {
+		attrs[m[1]] = strings.ReplaceAll(m[2], `\"`, `"`)
+	}
+	return attrs
+}
+
+// gonumGraphJSON is the shape of a gonum/graph-style adjacency JSON document:
+// a flat node list plus a from/to edge list, both keyed by node ID.
+type gonumGraphJSON struct {
+	Nodes []struct {
+		ID    string `json:"id"`
+		Label string `json:"label"`
+	} `json:"nodes"`
+	Edges []struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"edges"`
+}
+
+// importGonumJSON parses a gonum/graph-style adjacency JSON document.
+func importGonumJSON(data []byte) ([]NodeData, []EdgeData, error) {
+	var doc gonumGraphJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("error parsing gonum graph JSON: %v", err)
+	}
+
+	idToName := make(map[string]string, len(doc.Nodes))
+	nodes := make([]NodeData, 0, len(doc.Nodes))
+	for _, n := range doc.Nodes {
+		name := n.Label
+		if name == "" {
+			name = n.ID
+		}
+		idToName[n.ID] = name
+		nodes = append(nodes, NodeData{Name: name})
+	}
+
+	edges := make([]EdgeData, 0, len(doc.Edges))
+	for _, e := range doc.Edges {
+		edges = append(edges, EdgeData{
+			From: resolveImportID(idToName, e.From),
+			To:   resolveImportID(idToName, e.To),
+		})
+	}
+
+	return nodes, edges, nil
+}
+
+// pprofGraphJSON is a simplified pprof-style call-graph JSON document: a
+// flat list of functions (nodes) and weighted caller/callee edges between
+// them, referenced by index.
+type pprofGraphJSON struct {
+	Nodes []struct {
+		Name string `json:"name"`
+		File string `json:"file"`
+	} `json:"nodes"`
+	Edges []struct {
+		Source int    `json:"source"`
+		Target int    `json:"target"`
+		Weight string `json:"weight"`
+	} `json:"edges"`
+}
+
+// importPprofJSON parses a simplified pprof-style call-graph JSON document.
+func importPprofJSON(data []byte) ([]NodeData, []EdgeData, error) {
+	var doc pprofGraphJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("error parsing pprof graph JSON: %v", err)
+	}
+
+	nodes := make([]NodeData, 0, len(doc.Nodes))
+	for _, n := range doc.Nodes {
+		nodes = append(nodes, NodeData{Name: n.Name, Path: n.File})
+	}
+
+	edges := make([]EdgeData, 0, len(doc.Edges))
+	for _, e := range doc.Edges {
+		if e.Source < 0 || e.Source >= len(doc.Nodes) || e.Target < 0 || e.Target >= len(doc.Nodes) {
+			return nil, nil, fmt.Errorf("error: edge references out-of-range node index (source=%d, target=%d)", e.Source, e.Target)
+		}
+		edges = append(edges, EdgeData{
+			From:     doc.Nodes[e.Source].Name,
+			To:       doc.Nodes[e.Target].Name,
+			Relation: e.Weight,
+		})
+	}
+
+	return nodes, edges, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import "testing"
+
+func TestImportDOTResolvesEdgesByLabel(t *testing.T) {
+	src := `digraph G {
+n0 [label="alpha"];
+n1 [label="beta"];
+n0 -> n1 [label="uses"];
+}`
+	nodes, edges, err := importDOT([]byte(src))
+	if err != nil {
+		t.Fatalf("importDOT: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "alpha" || nodes[1].Name != "beta" {
+		t.Fatalf("unexpected nodes: %+v", nodes)
+	}
+	if len(edges) != 1 || edges[0].From != "alpha" || edges[0].To != "beta" {
+		t.Fatalf("edge should be keyed by label (Name), got: %+v", edges)
+	}
+}
+
+func TestImportDOTFallsBackToIDWhenNoLabel(t *testing.T) {
+	src := `digraph G {
+n0 [];
+n1 [];
+n0 -> n1;
+}`
+	nodes, edges, err := importDOT([]byte(src))
+	if err != nil {
+		t.Fatalf("importDOT: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "n0" || nodes[1].Name != "n1" {
+		t.Fatalf("unexpected nodes: %+v", nodes)
+	}
+	if len(edges) != 1 || edges[0].From != "n0" || edges[0].To != "n1" {
+		t.Fatalf("unexpected edges: %+v", edges)
+	}
+}
+
+func TestImportGonumJSONResolvesEdgesByLabel(t *testing.T) {
+	src := `{
+		"nodes": [{"id": "0", "label": "alpha"}, {"id": "1", "label": "beta"}],
+		"edges": [{"from": "0", "to": "1"}]
+	}`
+	nodes, edges, err := importGonumJSON([]byte(src))
+	if err != nil {
+		t.Fatalf("importGonumJSON: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "alpha" || nodes[1].Name != "beta" {
+		t.Fatalf("unexpected nodes: %+v", nodes)
+	}
+	if len(edges) != 1 || edges[0].From != "alpha" || edges[0].To != "beta" {
+		t.Fatalf("edge should be keyed by label (Name), got: %+v", edges)
+	}
+}
+
+func TestImportGonumJSONFallsBackToIDWhenNoLabel(t *testing.T) {
+	src := `{
+		"nodes": [{"id": "0"}, {"id": "1"}],
+		"edges": [{"from": "0", "to": "1"}]
+	}`
+	nodes, edges, err := importGonumJSON([]byte(src))
+	if err != nil {
+		t.Fatalf("importGonumJSON: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "0" || nodes[1].Name != "1" {
+		t.Fatalf("unexpected nodes: %+v", nodes)
+	}
+	if len(edges) != 1 || edges[0].From != "0" || edges[0].To != "1" {
+		t.Fatalf("unexpected edges: %+v", edges)
+	}
+}
+
+func TestImportPprofJSONRejectsOutOfRangeEdge(t *testing.T) {
+	src := `{
+		"nodes": [{"name": "a"}],
+		"edges": [{"source": 0, "target": 5}]
+	}`
+	if _, _, err := importPprofJSON([]byte(src)); err == nil {
+		t.Fatal("expected an error for an out-of-range edge target")
+	}
+}
+
+func TestBuildNodeIDsRejectsDuplicateNames(t *testing.T) {
+	nodes := []NodeData{{Name: "alpha"}, {Name: "alpha"}}
+	if _, err := buildNodeIDs(nodes); err == nil {
+		t.Fatal("expected an error for duplicate node names")
+	}
+}
+
+func TestRoundTripDOTOutputThroughImport(t *testing.T) {
+	// Regression test for a round-trip bug: nodes whose label differs from
+	// their DOT id (the common case for this tool's own -format dot output)
+	// used to produce edges keyed on the raw id, which buildDotGraph then
+	// couldn't resolve against nodes keyed by Name.
+	cfg := Config{Graph: GraphMetadata{Name: "G"}}
+	nodes := []NodeData{{Name: "alpha"}, {Name: "beta"}}
+	edges := []EdgeData{{From: "alpha", To: "beta"}}
+
+	g, _, err := buildDotGraph(cfg, nodes, edges)
+	if err != nil {
+		t.Fatalf("buildDotGraph: %v", err)
+	}
+	dotSource := g.Marshal()
+
+	importedNodes, importedEdges, err := importDOT(dotSource)
+	if err != nil {
+		t.Fatalf("importDOT: %v", err)
+	}
+	if _, _, err := buildDotGraph(cfg, importedNodes, importedEdges); err != nil {
+		t.Fatalf("buildDotGraph on round-tripped graph: %v", err)
+	}
+}
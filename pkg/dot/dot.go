@@ -0,0 +1,196 @@
+// Package dot provides a small in-memory builder for Graphviz DOT graphs.
+// It lets callers construct a graph programmatically (nodes, edges,
+// attributes, and clusters) and marshal it to well-formed DOT source,
+// without hand-rolling string concatenation.
+package dot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// identifierRE matches DOT identifiers that don't require quoting.
+var identifierRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Node is a single node in the graph, identified by ID with an optional set
+// of Graphviz attributes (label, URL, fillcolor, etc).
+type Node struct {
+	ID    string
+	Attrs map[string]string
+}
+
+// Edge is a directed edge between two node IDs with an optional set of
+// Graphviz attributes (label, color, style, etc).
+type Edge struct {
+	From, To string
+	Attrs    map[string]string
+}
+
+// Graph is an in-memory representation of a Graphviz digraph, including
+// nested subgraphs/clusters. Construct one with New and populate it with
+// AddNode, AddEdge and AddSubgraph.
+type Graph struct {
+	Name  string
+	Attrs map[string]string
+	Nodes []*Node
+	Edges []*Edge
+
+	// subgraphs holds nested (sub)graphs keyed by their ID; subgraphOrder
+	// keeps their IDs in insertion order. A subgraph whose ID begins with "cluster_" is rendered as a
+	// Graphviz cluster (boxed, with an optional label).
+	subgraphOrder []string
+	subgraphs     map[string]*Graph
+	parent        *Graph
+}
+
+// New creates an empty top-level graph with the given name.
+func New(name string) *Graph {
+	return &Graph{
+		Name:      name,
+		Attrs:     map[string]string{},
+		subgraphs: map[string]*Graph{},
+	}
+}
+
+// SetAttr sets a graph-level attribute (e.g. "bgcolor", "fontname").
+func (g *Graph) SetAttr(key, value string) {
+	g.Attrs[key] = value
+}
+
+// AddNode adds (or returns the existing) node with the given ID.
+func (g *Graph) AddNode(id string) *Node {
+	for _, n := range g.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	n := &Node{ID: id, Attrs: map[string]string{}}
+	g.Nodes = append(g.Nodes, n)
+	return n
+}
+
+// AddEdge adds a directed edge from -> to and returns it so callers can set
+// attributes on it.
+func (g *Graph) AddEdge(from, to string) *Edge {
+	e := &Edge{From: from, To: to, Attrs: map[string]string{}}
+	g.Edges = append(g.Edges, e)
+	return e
+}
+
+// AddSubgraph creates (or returns the existing) nested subgraph with the
+// given ID. Prefix the ID with "cluster_" for Graphviz to render it as a
+// bordered cluster.
+func (g *Graph) AddSubgraph(id string) *Graph {
+	if sub, ok := g.subgraphs[id]; ok {
+		return sub
+	}
+	sub := &Graph{
+		Name:      id,
+		Attrs:     map[string]string{},
+		subgraphs: map[string]*Graph{},
+		parent:    g,
+	}
+	g.subgraphs[id] = sub
+	g.subgraphOrder = append(g.subgraphOrder, id)
+	return sub
+}
+
+// SetAttr sets (k, v) on e.Attrs, skipping empty values; a convenience for
+// the common "only set attributes that were actually provided" pattern.
+func (e *Edge) SetAttr(key, value string) {
+	if value == "" {
+		return
+	}
+	e.Attrs[key] = value
+}
+
+// SetAttr sets (k, v) on n.Attrs, skipping empty values.
+func (n *Node) SetAttr(key, value string) {
+	if value == "" {
+		return
+	}
+	n.Attrs[key] = value
+}
+
+// Marshal renders the graph (and all nested subgraphs) to DOT source.
+func (g *Graph) Marshal() []byte {
+	var b strings.Builder
+	b.WriteString("digraph ")
+	b.WriteString(quoteID(g.Name))
+	b.WriteString(" {\n")
+	g.writeBody(&b, 1)
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+func (g *Graph) writeBody(b *strings.Builder, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	for _, key := range sortedKeys(g.Attrs) {
+		fmt.Fprintf(b, "%s%s=%s;\n", indent, key, quoteAttr(g.Attrs[key]))
+	}
+	for _, n := range g.Nodes {
+		fmt.Fprintf(b, "%s%s%s;\n", indent, quoteID(n.ID), attrList(n.Attrs))
+	}
+	for _, sub := range g.subgraphOrder {
+		s := g.subgraphs[sub]
+		fmt.Fprintf(b, "%ssubgraph %s {\n", indent, quoteID(sub))
+		s.writeBody(b, depth+1)
+		fmt.Fprintf(b, "%s}\n", indent)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "%s%s -> %s%s;\n", indent, quoteID(e.From), quoteID(e.To), attrList(e.Attrs))
+	}
+}
+
+// attrList renders a Graphviz attribute list ("[k1=v1, k2=v2]"), or an empty
+// string when there are no attributes, in sorted key order for stable
+// output.
+func attrList(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	keys := sortedKeys(attrs)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%s", k, quoteAttr(attrs[k]))
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// quoteID quotes a DOT identifier if it isn't already a valid bareword,
+// escaping embedded quotes and backslashes. DOT keywords (node, edge,
+// graph, digraph, subgraph, strict; case-insensitive) are always quoted.
+func quoteID(id string) string {
+	switch strings.ToLower(id) {
+	case "node", "edge", "graph", "digraph", "subgraph", "strict":
+		return `"` + escapeString(id) + `"`
+	}
+	if identifierRE.MatchString(id) {
+		return id
+	}
+	return `"` + escapeString(id) + `"`
+}
+
+// quoteAttr always quotes an attribute value, since Graphviz attribute
+// values are almost always free text (labels, URLs, colors with '#').
+func quoteAttr(value string) string {
+	return `"` + escapeString(value) + `"`
+}
+
+func escapeString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return s
+}
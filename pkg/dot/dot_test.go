@@ -0,0 +1,97 @@
+package dot
+
+import "testing"
+
+func TestQuoteID(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"n0", "n0"},
+		{"_cluster", "_cluster"},
+		{"cluster_a_b", "cluster_a_b"},
+		{"has space", `"has space"`},
+		{"has\"quote", `"has\"quote"`},
+		{`back\slash`, `"back\\slash"`},
+		{"", `""`},
+		{"1leading", `"1leading"`},
+	}
+	for _, c := range cases {
+		if got := quoteID(c.in); got != c.want {
+			t.Errorf("quoteID(%q) = %s, want %s", c.in, got, c.want)
+		}
+	}
+}
+
+func TestEscapeString(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{`a"b`, `a\"b`},
+		{`a\b`, `a\\b`},
+		{`a\"b`, `a\\\"b`},
+	}
+	for _, c := range cases {
+		if got := escapeString(c.in); got != c.want {
+			t.Errorf("escapeString(%q) = %s, want %s", c.in, got, c.want)
+		}
+	}
+}
+
+func TestMarshalNestedClusters(t *testing.T) {
+	g := New("G")
+	g.SetAttr("bgcolor", "white")
+
+	outer := g.AddSubgraph("cluster_outer")
+	outer.SetAttr("label", "Outer")
+	inner := outer.AddSubgraph("cluster_inner")
+	inner.SetAttr("label", "Inner")
+
+	n1 := inner.AddNode("n0")
+	n1.SetAttr("label", "A")
+	n2 := g.AddNode("n1")
+	n2.SetAttr("label", "B")
+
+	e := g.AddEdge("n0", "n1")
+	e.SetAttr("color", "red")
+
+	got := string(g.Marshal())
+	want := `digraph G {
+  bgcolor="white";
+  n1 [label="B"];
+  subgraph cluster_outer {
+    label="Outer";
+    subgraph cluster_inner {
+      label="Inner";
+      n0 [label="A"];
+    }
+  }
+  n0 -> n1 [color="red"];
+}
+`
+	if got != want {
+		t.Errorf("Marshal() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAddNodeReturnsExisting(t *testing.T) {
+	g := New("G")
+	a := g.AddNode("n0")
+	a.SetAttr("label", "A")
+	b := g.AddNode("n0")
+	if a != b {
+		t.Fatal("AddNode with an existing ID should return the same *Node")
+	}
+	if len(g.Nodes) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(g.Nodes))
+	}
+}
+
+func TestAddSubgraphReturnsExisting(t *testing.T) {
+	g := New("G")
+	a := g.AddSubgraph("cluster_x")
+	b := g.AddSubgraph("cluster_x")
+	if a != b {
+		t.Fatal("AddSubgraph with an existing ID should return the same *Graph")
+	}
+}
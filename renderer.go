@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// supportedFormats lists the Graphviz output formats this tool will pass
+// through to `dot -T<format>`. This mirrors the subset of formats Graphviz
+// itself documents as stable across versions.
+var supportedFormats = []string{"svg", "png", "pdf", "dot", "json", "plain", "xdot", "ps"}
+
+// isSupportedFormat reports whether format is one of supportedFormats.
+func isSupportedFormat(format string) bool {
+	for _, f := range supportedFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// detectFormat guesses the desired output format from an output file's
+// extension, falling back to "svg" when the extension is missing or
+// unrecognized.
+func detectFormat(outputFile string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(outputFile), "."))
+	switch ext {
+	case "svg", "png", "pdf", "dot", "json", "plain", "xdot", "ps":
+		return ext
+	case "gv":
+		return "dot"
+	default:
+		return "svg"
+	}
+}
+
+// Renderer turns DOT source into the bytes of a rendered graph in the given
+// format. Implementations may shell out to Graphviz or simply hand back the
+// DOT source unchanged.
+type Renderer interface {
+	Render(dot []byte, format string) ([]byte, error)
+}
+
+// GraphvizRenderer shells out to the `dot` binary to produce the requested
+// output format. It requires Graphviz to be installed and on PATH.
+type GraphvizRenderer struct{}
+
+func (GraphvizRenderer) Render(dot []byte, format string) ([]byte, error) {
+	if !isSupportedFormat(format) {
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
+
+	var in, out bytes.Buffer
+	in.Write(dot)
+
+	cmd := exec.Command("dot", "-T"+format)
+	cmd.Stdin = &in
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error running dot: %v", err)
+	}
+
+	p := out.Bytes()
+	if format == "svg" {
+		i := bytes.Index(p, []byte("<svg"))
+		if i < 0 {
+			return nil, errors.New("<svg not found")
+		}
+		p = p[i:]
+	}
+	return p, nil
+}
+
+// DotRenderer returns the DOT source as-is. It supports only the "dot"
+// format, but requires no external dependencies, which makes it useful for
+// debugging or environments without Graphviz installed.
+type DotRenderer struct{}
+
+func (DotRenderer) Render(dot []byte, format string) ([]byte, error) {
+	if format != "dot" {
+		return nil, fmt.Errorf("DotRenderer only supports the \"dot\" format, got %q", format)
+	}
+	return dot, nil
+}
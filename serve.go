@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// indexTemplate is the auto-refreshing HTML page served at "/". It opens an
+// SSE connection to /events and reloads the graph image whenever the server
+// says the graph changed.
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Name}}</title></head>
+<body>
+<h1>{{.Name}}</h1>
+<img id="graph" src="/graph.svg" alt="{{.Name}}">
+<script>
+var img = document.getElementById("graph");
+var events = new EventSource("/events");
+events.onmessage = function() {
+	img.src = "/graph.svg?t=" + Date.now();
+};
+</script>
+</body>
+</html>
+`))
+
+// graphServer holds the live state behind -serve: the current nodes/edges,
+// their last-rendered SVG, and the set of subscribers to notify (via SSE)
+// when that state changes.
+type graphServer struct {
+	mu    sync.Mutex
+	cfg   Config
+	nodes []NodeData
+	edges []EdgeData
+	svg   []byte
+
+	nodeColumns []string
+	nodeDelim   string
+	edgeColumns []string
+	edgeDelim   string
+
+	subsMu sync.Mutex
+	subs   map[chan struct{}]bool
+}
+
+func newGraphServer(cfg Config, nodes []NodeData, edges []EdgeData) *graphServer {
+	nodeColumns, nodeDelim := formatColumns(cfg.NodeFormat)
+	edgeColumns, edgeDelim := formatColumns(cfg.EdgeFormat)
+	return &graphServer{
+		cfg:         cfg,
+		nodes:       nodes,
+		edges:       edges,
+		nodeColumns: nodeColumns,
+		nodeDelim:   nodeDelim,
+		edgeColumns: edgeColumns,
+		edgeDelim:   edgeDelim,
+		subs:        map[chan struct{}]bool{},
+	}
+}
+
+// rerender re-renders the graph from the server's current nodes/edges and
+// notifies every /events subscriber. Callers must hold neither mu nor subsMu.
+func (s *graphServer) rerender() error {
+	s.mu.Lock()
+	svg, err := renderGraph(s.cfg, s.nodes, s.edges, "", GraphvizRenderer{}, "svg")
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.svg = svg
+	s.mu.Unlock()
+
+	s.subsMu.Lock()
+	for ch := range s.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	s.subsMu.Unlock()
+	return nil
+}
+
+func (s *graphServer) handleGraphSVG(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	svg := s.svg
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(svg)
+}
+
+func (s *graphServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	name := s.cfg.Graph.Name
+	s.mu.Unlock()
+	indexTemplate.Execute(w, struct{ Name string }{name})
+}
+
+func (s *graphServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan struct{}, 1)
+	s.subsMu.Lock()
+	s.subs[ch] = true
+	s.subsMu.Unlock()
+	defer func() {
+		s.subsMu.Lock()
+		delete(s.subs, ch)
+		s.subsMu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ch:
+			fmt.Fprintf(w, "data: reload\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleAddNodes accepts POST bodies in the same node text format as the
+// -nodes file and appends them to the live node set.
+func (s *graphServer) handleAddNodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	added, err := s.appendLines(r, func(line string) error {
+		node, err := parseNodeLine(line, s.nodeColumns, s.nodeDelim)
+		if err != nil {
+			return err
+		}
+		s.nodes = append(s.nodes, node)
+		return nil
+	})
+	s.respondToUpdate(w, added, err)
+}
+
+// handleAddEdges accepts POST bodies in the same edge text format as the
+// -edges file and appends them to the live edge set.
+func (s *graphServer) handleAddEdges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	added, err := s.appendLines(r, func(line string) error {
+		edge, err := parseEdgeLine(line, s.edgeColumns, s.edgeDelim)
+		if err != nil {
+			return err
+		}
+		s.edges = append(s.edges, edge)
+		return nil
+	})
+	s.respondToUpdate(w, added, err)
+}
+
+func (s *graphServer) appendLines(r *http.Request, appendOne func(line string) error) (int, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return 0, fmt.Errorf("error reading request body: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := appendOne(line); err != nil {
+			return added, err
+		}
+		added++
+	}
+	return added, nil
+}
+
+func (s *graphServer) respondToUpdate(w http.ResponseWriter, added int, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rerenderErr := s.rerender(); rerenderErr != nil {
+		http.Error(w, rerenderErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, "added %d\n", added)
+}
+
+// watchFiles watches nodesFile/edgesFile for changes and triggers a
+// re-parse-and-rerender whenever either is written.
+func (s *graphServer) watchFiles(nodesFile, edgesFile string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating file watcher: %v", err)
+	}
+	for _, f := range []string{nodesFile, edgesFile} {
+		if err := watcher.Add(f); err != nil {
+			log.Printf("warning: could not watch %s: %v", f, err)
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				if err := s.reloadFromDisk(nodesFile, edgesFile); err != nil {
+					log.Printf("error reloading %s: %v", event.Name, err)
+					continue
+				}
+				if err := s.rerender(); err != nil {
+					log.Printf("error re-rendering graph: %v", err)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("file watcher error: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *graphServer) reloadFromDisk(nodesFile, edgesFile string) error {
+	nodeData, err := readNodesFile(nodesFile, s.nodeColumns, s.nodeDelim)
+	if err != nil {
+		return err
+	}
+	edgeData, err := readEdgesFile(edgesFile, s.edgeColumns, s.edgeDelim)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.nodes = nodeData
+	s.edges = edgeData
+	s.mu.Unlock()
+	return nil
+}
+
+// serve starts the -serve HTTP server: it renders once immediately, watches
+// nodesFile/edgesFile for changes, and blocks serving the graph (and live
+// updates to it) until the process is killed.
+func serve(addr string, cfg Config, nodes []NodeData, edges []EdgeData, nodesFile, edgesFile string) error {
+	s := newGraphServer(cfg, nodes, edges)
+	if err := s.rerender(); err != nil {
+		return err
+	}
+	if err := s.watchFiles(nodesFile, edgesFile); err != nil {
+		return err
+	}
+
+	http.HandleFunc("/", s.handleIndex)
+	http.HandleFunc("/graph.svg", s.handleGraphSVG)
+	http.HandleFunc("/events", s.handleEvents)
+	http.HandleFunc("/nodes", s.handleAddNodes)
+	http.HandleFunc("/edges", s.handleAddEdges)
+
+	log.Printf("Serving %s on %s\n", cfg.Graph.Name, addr)
+	return http.ListenAndServe(addr, nil)
+}